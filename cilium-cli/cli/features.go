@@ -5,6 +5,10 @@ package cli
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -21,6 +25,8 @@ func newCmdFeatures() *cobra.Command {
 		Aliases: []string{"fs"},
 	}
 	cmd.AddCommand(newCmdFeaturesStatus())
+	cmd.AddCommand(newCmdFeaturesWatch())
+	cmd.AddCommand(newCmdFeaturesExport())
 	return cmd
 }
 
@@ -39,10 +45,132 @@ func newCmdFeaturesStatus() *cobra.Command {
 			return nil
 		},
 	}
-	cmd.Flags().StringVar(&params.AgentPodSelector, "agent-pod-selector", defaults.AgentPodSelector, "Label on cilium-agent pods to select with")
-	cmd.Flags().StringVar(&params.NodeName, "node", "", "Node from which features status will be fetched, omit to select all nodes")
-	cmd.Flags().DurationVar(&params.WaitDuration, "wait-duration", 1*time.Minute, "Maximum time to wait for result, default 1 minute")
+	addFeaturesSelectorFlags(cmd, &params)
 	cmd.Flags().StringVarP(&params.Output, "output", "o", "tab", "Output format. One of: tab, markdown, json")
 	cmd.Flags().StringVarP(&params.Outputfile, "output-file", "", "-", "Outputs into a file. Defaults to stdout")
 	return cmd
 }
+
+func newCmdFeaturesWatch() *cobra.Command {
+	params := features.Parameters{}
+	var interval time.Duration
+	cmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Watch for feature status changes across the cluster",
+		Long:  "Polls all agents at --interval and prints only node-joined/left and feature-toggle diffs, instead of the full feature matrix",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			params.CiliumNamespace = namespace
+			s := features.NewFeatures(k8sClient, params)
+			return watchFeatures(cmd.Context(), s, interval, params.Output)
+		},
+	}
+	addFeaturesSelectorFlags(cmd, &params)
+	cmd.Flags().DurationVar(&interval, "interval", 30*time.Second, "Interval between polls of the agents")
+	cmd.Flags().StringVarP(&params.Output, "output", "o", "table", "Output format for diffs. One of: table, json")
+	return cmd
+}
+
+func newCmdFeaturesExport() *cobra.Command {
+	params := features.Parameters{}
+	var outputFile string
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the feature matrix as Prometheus text-format metrics",
+		Long:  "Renders the aggregated feature matrix as Prometheus text-format metrics, suitable for scraping or the node_exporter textfile collector",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			params.CiliumNamespace = namespace
+			s := features.NewFeatures(k8sClient, params)
+			matrix, err := s.CollectFeatureMatrix(cmd.Context())
+			if err != nil {
+				fatalf("Unable to collect feature matrix: %s", err)
+			}
+
+			out := os.Stdout
+			if outputFile != "-" && outputFile != "" {
+				f, err := os.Create(outputFile)
+				if err != nil {
+					fatalf("Unable to create output file %s: %s", outputFile, err)
+				}
+				defer f.Close()
+				out = f
+			}
+			return writeFeatureMetrics(out, matrix)
+		},
+	}
+	addFeaturesSelectorFlags(cmd, &params)
+	cmd.Flags().StringVarP(&outputFile, "output-file", "", "-", "Outputs into a file. Defaults to stdout")
+	return cmd
+}
+
+func addFeaturesSelectorFlags(cmd *cobra.Command, params *features.Parameters) {
+	cmd.Flags().StringVar(&params.AgentPodSelector, "agent-pod-selector", defaults.AgentPodSelector, "Label on cilium-agent pods to select with")
+	cmd.Flags().StringVar(&params.NodeName, "node", "", "Node from which features status will be fetched, omit to select all nodes")
+	cmd.Flags().DurationVar(&params.WaitDuration, "wait-duration", 1*time.Minute, "Maximum time to wait for result, default 1 minute")
+}
+
+// watchFeatures polls the cluster every interval and prints only the
+// differences between successive polls: nodes joining or leaving, and
+// features toggling on or off. A pod that is temporarily unreachable is
+// logged and skipped rather than aborting the whole watch.
+func watchFeatures(ctx context.Context, s *features.Features, interval time.Duration, output string) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var previous features.Matrix
+
+	for {
+		matrix, err := s.CollectFeatureMatrix(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Skipping this poll, unable to collect feature matrix: %s\n", err)
+		} else {
+			for _, diff := range features.DiffMatrix(previous, matrix) {
+				if err := printFeatureDiff(os.Stdout, diff, output); err != nil {
+					return err
+				}
+			}
+			previous = matrix
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func printFeatureDiff(w io.Writer, diff features.MatrixDiff, output string) error {
+	if output == "json" {
+		return json.NewEncoder(w).Encode(diff)
+	}
+
+	switch diff.Kind {
+	case features.DiffNodeJoined:
+		fmt.Fprintf(w, "%s\tnode joined\n", diff.Node)
+	case features.DiffNodeLeft:
+		fmt.Fprintf(w, "%s\tnode left\n", diff.Node)
+	case features.DiffFeatureEnabled:
+		fmt.Fprintf(w, "%s\t%s\tenabled\n", diff.Node, diff.Feature)
+	case features.DiffFeatureDisabled:
+		fmt.Fprintf(w, "%s\t%s\tdisabled\n", diff.Node, diff.Feature)
+	}
+	return nil
+}
+
+// writeFeatureMetrics renders matrix as Prometheus text-format metrics, one
+// cilium_feature_enabled gauge per feature/node pair.
+func writeFeatureMetrics(w io.Writer, matrix features.Matrix) error {
+	fmt.Fprintln(w, "# HELP cilium_feature_enabled Whether a Cilium feature is enabled on a given node")
+	fmt.Fprintln(w, "# TYPE cilium_feature_enabled gauge")
+
+	for _, node := range matrix.SortedNodes() {
+		for _, feature := range matrix.SortedFeatures(node) {
+			value := 0
+			if matrix.IsEnabled(node, feature) {
+				value = 1
+			}
+			fmt.Fprintf(w, "cilium_feature_enabled{feature=%q,node=%q} %d\n", feature, node, value)
+		}
+	}
+	return nil
+}
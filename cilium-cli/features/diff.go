@@ -0,0 +1,59 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package features
+
+// DiffKind identifies what kind of change a MatrixDiff describes.
+type DiffKind string
+
+const (
+	DiffNodeJoined      DiffKind = "node-joined"
+	DiffNodeLeft        DiffKind = "node-left"
+	DiffFeatureEnabled  DiffKind = "feature-enabled"
+	DiffFeatureDisabled DiffKind = "feature-disabled"
+)
+
+// MatrixDiff describes a single change between two Matrix snapshots: a node
+// joining or leaving the cluster, or a feature toggling on an existing
+// node. Feature is empty for DiffNodeJoined/DiffNodeLeft.
+type MatrixDiff struct {
+	Kind    DiffKind `json:"kind"`
+	Node    string   `json:"node"`
+	Feature string   `json:"feature,omitempty"`
+}
+
+// DiffMatrix compares previous against current and returns every node that
+// joined or left, plus every feature that toggled on a node present in
+// both. An empty previous Matrix (e.g. the first poll) reports every node
+// in current as joined, not every feature as enabled, since there is
+// nothing meaningful to diff against yet.
+func DiffMatrix(previous, current Matrix) []MatrixDiff {
+	var diffs []MatrixDiff
+
+	for _, node := range current.SortedNodes() {
+		if !previous.HasNode(node) {
+			diffs = append(diffs, MatrixDiff{Kind: DiffNodeJoined, Node: node})
+			continue
+		}
+		for _, feature := range current.SortedFeatures(node) {
+			wasEnabled := previous.IsEnabled(node, feature)
+			isEnabled := current.IsEnabled(node, feature)
+			if wasEnabled == isEnabled {
+				continue
+			}
+			kind := DiffFeatureDisabled
+			if isEnabled {
+				kind = DiffFeatureEnabled
+			}
+			diffs = append(diffs, MatrixDiff{Kind: kind, Node: node, Feature: feature})
+		}
+	}
+
+	for _, node := range previous.SortedNodes() {
+		if !current.HasNode(node) {
+			diffs = append(diffs, MatrixDiff{Kind: DiffNodeLeft, Node: node})
+		}
+	}
+
+	return diffs
+}
@@ -0,0 +1,51 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package features
+
+import "testing"
+
+func TestDiffMatrixFirstPollReportsOnlyJoins(t *testing.T) {
+	var previous Matrix
+	current := NewMatrix()
+	current.Set("node-a", "wireguard", true)
+
+	diffs := DiffMatrix(previous, current)
+	if len(diffs) != 1 || diffs[0].Kind != DiffNodeJoined || diffs[0].Node != "node-a" {
+		t.Fatalf("expected a single node-joined diff for node-a, got %+v", diffs)
+	}
+}
+
+func TestDiffMatrixDetectsFeatureToggle(t *testing.T) {
+	previous := NewMatrix()
+	previous.Set("node-a", "wireguard", false)
+
+	current := NewMatrix()
+	current.Set("node-a", "wireguard", true)
+
+	diffs := DiffMatrix(previous, current)
+	if len(diffs) != 1 || diffs[0].Kind != DiffFeatureEnabled || diffs[0].Node != "node-a" || diffs[0].Feature != "wireguard" {
+		t.Fatalf("expected a single feature-enabled diff for node-a/wireguard, got %+v", diffs)
+	}
+}
+
+func TestDiffMatrixDetectsNodeLeft(t *testing.T) {
+	previous := NewMatrix()
+	previous.Set("node-a", "wireguard", true)
+
+	current := NewMatrix()
+
+	diffs := DiffMatrix(previous, current)
+	if len(diffs) != 1 || diffs[0].Kind != DiffNodeLeft || diffs[0].Node != "node-a" {
+		t.Fatalf("expected a single node-left diff for node-a, got %+v", diffs)
+	}
+}
+
+func TestDiffMatrixNoChangeReportsNothing(t *testing.T) {
+	m := NewMatrix()
+	m.Set("node-a", "wireguard", true)
+
+	if diffs := DiffMatrix(m, m); len(diffs) != 0 {
+		t.Fatalf("expected no diffs for an unchanged matrix, got %+v", diffs)
+	}
+}
@@ -0,0 +1,9 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+// Package features collects which Cilium features are enabled on each
+// cilium-agent pod in a cluster and renders the result as a point-in-time
+// status report (PrintFeatureStatus), a full Matrix for programmatic
+// consumers (CollectFeatureMatrix), or a diff between two Matrix snapshots
+// (DiffMatrix) so that a poller only has to print what changed.
+package features
@@ -0,0 +1,145 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package features
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// Parameters selects which cilium-agent pods a Features run talks to and
+// how it renders what it finds.
+type Parameters struct {
+	CiliumNamespace  string
+	AgentPodSelector string
+	NodeName         string
+	WaitDuration     time.Duration
+
+	Output     string
+	Outputfile string
+}
+
+// AgentPod identifies a single cilium-agent pod to query.
+type AgentPod struct {
+	Node string
+	Name string
+}
+
+// K8sClient is the subset of cluster access Features needs: discovering
+// cilium-agent pods and asking each one for its enabled features. The cli
+// package's k8sClient wraps the real cluster connection; this interface
+// keeps Features testable without one.
+type K8sClient interface {
+	ListCiliumAgentPods(ctx context.Context, namespace, podSelector, nodeName string) ([]AgentPod, error)
+	FeatureStatus(ctx context.Context, pod AgentPod) (map[string]bool, error)
+}
+
+// Features collects and reports feature status across a set of
+// cilium-agent pods.
+type Features struct {
+	client K8sClient
+	params Parameters
+
+	// Warnf receives one message per pod that failed to report its feature
+	// status so collection can keep going instead of aborting the whole
+	// poll. Defaults to writing to os.Stderr.
+	Warnf func(format string, args ...any)
+}
+
+// NewFeatures returns a Features that queries client for the agent pods
+// matching params.
+func NewFeatures(client K8sClient, params Parameters) *Features {
+	return &Features{
+		client: client,
+		params: params,
+		Warnf:  func(format string, args ...any) { fmt.Fprintf(os.Stderr, format+"\n", args...) },
+	}
+}
+
+// CollectFeatureMatrix queries every matching cilium-agent pod for its
+// feature status and aggregates the results into a Matrix. A pod that
+// fails to report (e.g. temporarily unreachable, mid-restart) is skipped
+// with a warning rather than failing the whole collection; an error is
+// only returned if the pods cannot be listed or every matching pod failed.
+func (f *Features) CollectFeatureMatrix(ctx context.Context) (Matrix, error) {
+	pods, err := f.client.ListCiliumAgentPods(ctx, f.params.CiliumNamespace, f.params.AgentPodSelector, f.params.NodeName)
+	if err != nil {
+		return Matrix{}, fmt.Errorf("listing cilium-agent pods: %w", err)
+	}
+
+	matrix := NewMatrix()
+	var failures int
+	for _, pod := range pods {
+		status, err := f.client.FeatureStatus(ctx, pod)
+		if err != nil {
+			f.Warnf("Skipping node %s, unable to fetch feature status: %s", pod.Node, err)
+			failures++
+			continue
+		}
+		for feature, enabled := range status {
+			matrix.Set(pod.Node, feature, enabled)
+		}
+	}
+
+	if len(pods) > 0 && failures == len(pods) {
+		return Matrix{}, fmt.Errorf("unable to fetch feature status from any of %d matching pods", len(pods))
+	}
+	return matrix, nil
+}
+
+// PrintFeatureStatus collects the feature matrix and renders it to
+// params.Outputfile (stdout by default) in params.Output format (tab,
+// markdown or json).
+func (f *Features) PrintFeatureStatus(ctx context.Context) error {
+	matrix, err := f.CollectFeatureMatrix(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := os.Stdout
+	if f.params.Outputfile != "" && f.params.Outputfile != "-" {
+		file, err := os.Create(f.params.Outputfile)
+		if err != nil {
+			return fmt.Errorf("creating output file %s: %w", f.params.Outputfile, err)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	switch f.params.Output {
+	case "json":
+		return json.NewEncoder(w).Encode(matrix)
+	case "markdown":
+		return writeFeatureMarkdown(w, matrix)
+	default:
+		return writeFeatureTable(w, matrix)
+	}
+}
+
+func writeFeatureTable(w io.Writer, matrix Matrix) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NODE\tFEATURE\tENABLED")
+	for _, node := range matrix.SortedNodes() {
+		for _, feature := range matrix.SortedFeatures(node) {
+			fmt.Fprintf(tw, "%s\t%s\t%t\n", node, feature, matrix.IsEnabled(node, feature))
+		}
+	}
+	return tw.Flush()
+}
+
+func writeFeatureMarkdown(w io.Writer, matrix Matrix) error {
+	fmt.Fprintln(w, "| Node | Feature | Enabled |")
+	fmt.Fprintln(w, "|------|---------|---------|")
+	for _, node := range matrix.SortedNodes() {
+		for _, feature := range matrix.SortedFeatures(node) {
+			fmt.Fprintf(w, "| %s | %s | %t |\n", node, feature, matrix.IsEnabled(node, feature))
+		}
+	}
+	return nil
+}
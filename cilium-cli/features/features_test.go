@@ -0,0 +1,66 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package features
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type fakeK8sClient struct {
+	pods    []AgentPod
+	status  map[string]map[string]bool
+	failing map[string]bool
+}
+
+func (f *fakeK8sClient) ListCiliumAgentPods(_ context.Context, _, _, _ string) ([]AgentPod, error) {
+	return f.pods, nil
+}
+
+func (f *fakeK8sClient) FeatureStatus(_ context.Context, pod AgentPod) (map[string]bool, error) {
+	if f.failing[pod.Name] {
+		return nil, errors.New("connection refused")
+	}
+	return f.status[pod.Name], nil
+}
+
+func TestCollectFeatureMatrixSkipsFailingPods(t *testing.T) {
+	client := &fakeK8sClient{
+		pods: []AgentPod{
+			{Node: "node-a", Name: "cilium-a"},
+			{Node: "node-b", Name: "cilium-b"},
+		},
+		status: map[string]map[string]bool{
+			"cilium-a": {"wireguard": true},
+		},
+		failing: map[string]bool{"cilium-b": true},
+	}
+	f := NewFeatures(client, Parameters{})
+	f.Warnf = func(string, ...any) {} // silence expected warning in test output
+
+	matrix, err := f.CollectFeatureMatrix(context.Background())
+	if err != nil {
+		t.Fatalf("expected collection to succeed despite one failing pod, got %s", err)
+	}
+	if !matrix.IsEnabled("node-a", "wireguard") {
+		t.Fatalf("expected node-a's feature status to be recorded")
+	}
+	if matrix.HasNode("node-b") {
+		t.Fatalf("expected node-b to be skipped, not recorded with empty status")
+	}
+}
+
+func TestCollectFeatureMatrixErrorsWhenEveryPodFails(t *testing.T) {
+	client := &fakeK8sClient{
+		pods:    []AgentPod{{Node: "node-a", Name: "cilium-a"}},
+		failing: map[string]bool{"cilium-a": true},
+	}
+	f := NewFeatures(client, Parameters{})
+	f.Warnf = func(string, ...any) {}
+
+	if _, err := f.CollectFeatureMatrix(context.Background()); err == nil {
+		t.Fatalf("expected an error when every matching pod fails to report")
+	}
+}
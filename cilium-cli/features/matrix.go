@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package features
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// Matrix is a snapshot of which features are enabled on which nodes, as
+// collected by Features.CollectFeatureMatrix.
+type Matrix struct {
+	// nodes maps node name to feature name to whether it's enabled.
+	nodes map[string]map[string]bool
+}
+
+// NewMatrix returns an empty Matrix.
+func NewMatrix() Matrix {
+	return Matrix{nodes: make(map[string]map[string]bool)}
+}
+
+// Set records whether feature is enabled on node.
+func (m *Matrix) Set(node, feature string, enabled bool) {
+	if m.nodes == nil {
+		m.nodes = make(map[string]map[string]bool)
+	}
+	if m.nodes[node] == nil {
+		m.nodes[node] = make(map[string]bool)
+	}
+	m.nodes[node][feature] = enabled
+}
+
+// IsEnabled reports whether feature is enabled on node. It returns false
+// for a node or feature the matrix has no record of.
+func (m Matrix) IsEnabled(node, feature string) bool {
+	return m.nodes[node][feature]
+}
+
+// HasNode reports whether the matrix has any record for node.
+func (m Matrix) HasNode(node string) bool {
+	_, ok := m.nodes[node]
+	return ok
+}
+
+// SortedNodes returns every node in the matrix, sorted by name.
+func (m Matrix) SortedNodes() []string {
+	nodes := make([]string, 0, len(m.nodes))
+	for node := range m.nodes {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+// SortedFeatures returns every feature recorded for node, sorted by name.
+func (m Matrix) SortedFeatures(node string) []string {
+	features := make([]string, 0, len(m.nodes[node]))
+	for feature := range m.nodes[node] {
+		features = append(features, feature)
+	}
+	sort.Strings(features)
+	return features
+}
+
+// MarshalJSON renders the matrix as {"node": {"feature": enabled}}.
+func (m Matrix) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.nodes)
+}
@@ -0,0 +1,38 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package features
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatrixSortedNodesAndFeatures(t *testing.T) {
+	m := NewMatrix()
+	m.Set("node-b", "bandwidth-manager", true)
+	m.Set("node-a", "wireguard", false)
+	m.Set("node-a", "bandwidth-manager", true)
+
+	if got, want := m.SortedNodes(), []string{"node-a", "node-b"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("SortedNodes() = %v, want %v", got, want)
+	}
+	if got, want := m.SortedFeatures("node-a"), []string{"bandwidth-manager", "wireguard"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("SortedFeatures(node-a) = %v, want %v", got, want)
+	}
+}
+
+func TestMatrixIsEnabled(t *testing.T) {
+	m := NewMatrix()
+	m.Set("node-a", "wireguard", true)
+
+	if !m.IsEnabled("node-a", "wireguard") {
+		t.Fatalf("expected wireguard to be enabled on node-a")
+	}
+	if m.IsEnabled("node-a", "bandwidth-manager") {
+		t.Fatalf("expected an unrecorded feature to report disabled, not enabled")
+	}
+	if m.IsEnabled("node-b", "wireguard") {
+		t.Fatalf("expected an unrecorded node to report disabled, not enabled")
+	}
+}
@@ -0,0 +1,99 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package ciliumidentity
+
+import (
+	"github.com/cilium/hive/cell"
+	"github.com/spf13/pflag"
+	corev1 "k8s.io/api/core/v1"
+
+	cilium_api_v2 "github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2"
+	"github.com/cilium/cilium/pkg/k8s/client/clientset/versioned"
+	"github.com/cilium/cilium/pkg/k8s/resource"
+)
+
+// Cell provides the operator-managed CiliumIdentity controller. It is the
+// operator-side counterpart of the EnableOperatorManageCIDs flag consumed by
+// pkg/identity/cache/cell; when enabled, the operator becomes the sole
+// allocator and garbage collector of CiliumIdentity resources.
+var Cell = cell.Module(
+	"cilium-identity",
+	"Centrally allocates and garbage collects Cilium Identities",
+
+	cell.Config(defaultConfig),
+	cell.Invoke(registerController),
+)
+
+type config struct {
+	// EnableOperatorManageCIDs mirrors the agent's hidden
+	// operator-manages-identities flag. It must be enabled on both the
+	// operator and every agent in the cluster; see pkg/identity/cache/cell.
+	EnableOperatorManageCIDs bool `mapstructure:"operator-manages-identities"`
+
+	// CIDAllocationRatePerSecond bounds how many CiliumIdentity creations the
+	// operator will perform per second, to protect the kube-apiserver from
+	// bursts of pod churn (e.g. a large deployment rollout).
+	CIDAllocationRatePerSecond float64 `mapstructure:"cid-allocation-rate-per-second"`
+
+	// CIDAllocationBurst is the maximum number of allocations allowed to
+	// happen back-to-back before rate limiting kicks in.
+	CIDAllocationBurst int `mapstructure:"cid-allocation-burst"`
+}
+
+func (c config) Flags(flags *pflag.FlagSet) {
+	flags.Bool("operator-manages-identities", c.EnableOperatorManageCIDs, "Enables the operator to centrally allocate and garbage collect Cilium Identities")
+	flags.MarkHidden("operator-manages-identities") // See https://github.com/cilium/cilium/issues/34675
+	flags.Float64("cid-allocation-rate-per-second", c.CIDAllocationRatePerSecond, "Maximum number of CiliumIdentity allocations the operator will perform per second")
+	flags.Int("cid-allocation-burst", c.CIDAllocationBurst, "Maximum number of CiliumIdentity allocations allowed to burst above the steady-state rate")
+}
+
+var defaultConfig = config{
+	EnableOperatorManageCIDs:   false,
+	CIDAllocationRatePerSecond: 50,
+	CIDAllocationBurst:         100,
+}
+
+type controllerParams struct {
+	cell.In
+
+	Lifecycle cell.Lifecycle
+
+	// LeaderElection is optional because nothing in this tree provides a
+	// real implementation yet; registerController falls back to
+	// alwaysLeader when it's nil, so the hive can still construct this
+	// cell. See alwaysLeader's doc comment for why that fallback is not
+	// safe to run with more than one operator replica.
+	LeaderElection LeaderElection `optional:"true"`
+	Clientset      versioned.Interface
+
+	Pods             resource.Resource[*corev1.Pod]
+	Namespaces       resource.Resource[*corev1.Namespace]
+	CiliumEndpoints  resource.Resource[*cilium_api_v2.CiliumEndpoint]
+	CiliumIdentities resource.Resource[*cilium_api_v2.CiliumIdentity]
+
+	Config config
+}
+
+func registerController(params controllerParams) {
+	if !params.Config.EnableOperatorManageCIDs {
+		return
+	}
+
+	if params.LeaderElection == nil {
+		log.Warning("No LeaderElection provided; treating this operator replica as always leading. Do not run more than one replica with operator-manages-identities enabled until a real LeaderElection is wired in")
+		params.LeaderElection = newAlwaysLeader()
+	}
+
+	c := newController(params, params.Config)
+
+	params.Lifecycle.Append(cell.Hook{
+		OnStart: func(ctx cell.HookContext) error {
+			return c.start(ctx)
+		},
+		OnStop: func(ctx cell.HookContext) error {
+			c.stop()
+			return nil
+		},
+	})
+}
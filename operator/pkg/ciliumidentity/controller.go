@@ -0,0 +1,257 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package ciliumidentity
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"golang.org/x/time/rate"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	cilium_api_v2 "github.com/cilium/cilium/pkg/k8s/apis/cilium.io/v2"
+	"github.com/cilium/cilium/pkg/k8s/client/clientset/versioned"
+	"github.com/cilium/cilium/pkg/k8s/resource"
+	"github.com/cilium/cilium/pkg/logging"
+	"github.com/cilium/cilium/pkg/logging/logfields"
+)
+
+var log = logging.DefaultLogger.WithField(logfields.LogSubsys, "ciliumidentity-operator")
+
+// startingNumericIdentity is a placeholder local-scope base for
+// operator-allocated identities. It does not yet coordinate with the
+// ranges pkg/identity/cache reserves for agent-local and cluster-wide
+// allocation; doing so safely needs the CiliumIdentityRequest API so
+// agents stop picking numeric IDs independently, which is tracked
+// separately from this reconcile loop.
+const startingNumericIdentity = 65536
+
+// controller centrally allocates and garbage collects CiliumIdentity
+// resources. It only does work while this operator instance holds the
+// leader lease; on losing leadership its subscriptions are torn down so
+// that the newly elected leader starts from a clean slate.
+type controller struct {
+	pods             resource.Resource[*corev1.Pod]
+	namespaces       resource.Resource[*corev1.Namespace]
+	ciliumEndpoints  resource.Resource[*cilium_api_v2.CiliumEndpoint]
+	ciliumIdentities resource.Resource[*cilium_api_v2.CiliumIdentity]
+
+	clientset      versioned.Interface
+	leaderElection LeaderElection
+	limiter        *rate.Limiter
+	index          *identityIndex
+
+	mu         sync.Mutex
+	cancelRun  context.CancelFunc
+	unregister func()
+}
+
+func newController(params controllerParams, cfg config) *controller {
+	return &controller{
+		pods:             params.Pods,
+		namespaces:       params.Namespaces,
+		ciliumEndpoints:  params.CiliumEndpoints,
+		ciliumIdentities: params.CiliumIdentities,
+		clientset:        params.Clientset,
+		leaderElection:   params.LeaderElection,
+		limiter:          rate.NewLimiter(rate.Limit(cfg.CIDAllocationRatePerSecond), cfg.CIDAllocationBurst),
+		index:            newIdentityIndex(startingNumericIdentity),
+	}
+}
+
+func (c *controller) start(ctx context.Context) error {
+	c.unregister = c.leaderElection.OnLeaderUpdate(func(isLeader bool) {
+		if isLeader {
+			c.onStartLeading(ctx)
+		} else {
+			c.onStopLeading()
+		}
+	})
+	return nil
+}
+
+func (c *controller) stop() {
+	if c.unregister != nil {
+		c.unregister()
+	}
+	c.onStopLeading()
+}
+
+func (c *controller) onStartLeading(ctx context.Context) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cancelRun != nil {
+		// Already running; nothing to do.
+		return
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	c.cancelRun = cancel
+
+	log.Info("Acquired leader lease, starting CiliumIdentity controller")
+	go c.run(runCtx)
+}
+
+func (c *controller) onStopLeading() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cancelRun == nil {
+		return
+	}
+	log.Info("Lost leader lease, stopping CiliumIdentity controller")
+	c.cancelRun()
+	c.cancelRun = nil
+}
+
+// run fans the four watched resource kinds into per-kind handlers and
+// blocks until ctx is cancelled, i.e. until this operator stops being the
+// leader. Pod events drive allocation and GC; Namespace and CiliumEndpoint
+// events are observed so a future revision can fold namespace labels into
+// hashLabels and use CiliumEndpoint as a faster-settling GC signal, but
+// don't yet change allocation decisions. CiliumIdentity events let the
+// index notice identities this operator owns being deleted out-of-band.
+func (c *controller) run(ctx context.Context) {
+	podEvents := c.pods.Events(ctx)
+	nsEvents := c.namespaces.Events(ctx)
+	cepEvents := c.ciliumEndpoints.Events(ctx)
+	cidEvents := c.ciliumIdentities.Events(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case ev, ok := <-podEvents:
+			if !ok {
+				podEvents = nil
+				continue
+			}
+			c.handlePodEvent(ctx, ev)
+
+		case ev, ok := <-nsEvents:
+			if !ok {
+				nsEvents = nil
+				continue
+			}
+			ev.Done(nil)
+
+		case ev, ok := <-cepEvents:
+			if !ok {
+				cepEvents = nil
+				continue
+			}
+			ev.Done(nil)
+
+		case ev, ok := <-cidEvents:
+			if !ok {
+				cidEvents = nil
+				continue
+			}
+			c.handleIdentityEvent(ev)
+		}
+	}
+}
+
+func (c *controller) handlePodEvent(ctx context.Context, ev resource.Event[*corev1.Pod]) {
+	if ev.Kind == resource.Sync {
+		ev.Done(nil)
+		return
+	}
+
+	if err := c.limiter.Wait(ctx); err != nil {
+		// Context was cancelled (leadership lost or operator stopping); the
+		// event will be redelivered to whichever operator instance takes
+		// over as leader.
+		ev.Done(err)
+		return
+	}
+
+	ev.Done(c.reconcilePod(ctx, ev))
+}
+
+// reconcilePod allocates a CiliumIdentity for the pod's label set on
+// upsert, and releases its reference on delete, garbage collecting the
+// CiliumIdentity once no pod references it any longer.
+func (c *controller) reconcilePod(ctx context.Context, ev resource.Event[*corev1.Pod]) error {
+	podKey := ev.Key.String()
+
+	if ev.Kind == resource.Delete {
+		return c.releasePod(ctx, podKey)
+	}
+
+	pod := ev.Object
+	securityLabels := securityRelevantLabels(pod.Labels)
+	hash := hashLabels(securityLabels)
+
+	result, released := c.index.ensure(podKey, hash)
+	if released != nil {
+		if err := c.deleteIdentity(ctx, released.id); err != nil {
+			return fmt.Errorf("garbage collecting identity %d after %s's labels changed: %w", released.id, podKey, err)
+		}
+	}
+	if !result.created {
+		return nil
+	}
+	if err := c.createIdentity(ctx, result.id, hash, securityLabels); err != nil {
+		return fmt.Errorf("allocating identity %d for %s: %w", result.id, podKey, err)
+	}
+	return nil
+}
+
+func (c *controller) releasePod(ctx context.Context, podKey string) error {
+	gc := c.index.release(podKey)
+	if gc == nil {
+		return nil
+	}
+	if err := c.deleteIdentity(ctx, gc.id); err != nil {
+		return fmt.Errorf("garbage collecting identity %d after %s was deleted: %w", gc.id, podKey, err)
+	}
+	return nil
+}
+
+// handleIdentityEvent keeps the index consistent if a CiliumIdentity this
+// operator owns is deleted out-of-band, e.g. by a user running kubectl
+// delete. It never allocates: CiliumIdentity creation is always driven by
+// Pod reconciliation.
+func (c *controller) handleIdentityEvent(ev resource.Event[*cilium_api_v2.CiliumIdentity]) {
+	if ev.Kind == resource.Delete && ev.Object != nil {
+		if id, err := strconv.ParseUint(ev.Object.Name, 10, 32); err == nil {
+			c.index.forgetID(uint32(id))
+		}
+	}
+	ev.Done(nil)
+}
+
+func (c *controller) createIdentity(ctx context.Context, id uint32, hash string, securityLabels map[string]string) error {
+	cid := &cilium_api_v2.CiliumIdentity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: strconv.FormatUint(uint64(id), 10),
+			Labels: map[string]string{
+				"operator.cilium.io/labels-hash": hash,
+			},
+		},
+		SecurityLabels: securityLabels,
+	}
+
+	_, err := c.clientset.CiliumV2().CiliumIdentities().Create(ctx, cid, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		return nil
+	}
+	return err
+}
+
+func (c *controller) deleteIdentity(ctx context.Context, id uint32) error {
+	name := strconv.FormatUint(uint64(id), 10)
+	err := c.clientset.CiliumV2().CiliumIdentities().Delete(ctx, name, metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
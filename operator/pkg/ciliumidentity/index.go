@@ -0,0 +1,134 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package ciliumidentity
+
+import "sync"
+
+// identityRecord tracks the set of pods currently sharing one allocated
+// CiliumIdentity.
+type identityRecord struct {
+	id     uint32
+	hash   string
+	owners map[string]struct{}
+}
+
+// identityIndex is the in-memory bookkeeping the controller uses to decide
+// when a CiliumIdentity needs to be created or garbage collected. It holds
+// no Kubernetes state of its own; ensure/release only report what the
+// caller must do, leaving the actual CiliumIdentity create/delete calls to
+// the controller so this type stays unit-testable without a clientset.
+type identityIndex struct {
+	mu sync.Mutex
+
+	byHash  map[string]*identityRecord
+	podHash map[string]string // pod key -> hash of its current security labels
+
+	nextID uint32
+}
+
+// newIdentityIndex creates an index that allocates numeric identities
+// starting at startID. In a real cluster startID would come from the same
+// local-scope range pkg/identity/cache reserves for agent-local allocation,
+// so operator-issued and agent-issued identities can never collide; wiring
+// that shared range is tracked alongside the CiliumIdentityRequest API.
+func newIdentityIndex(startID uint32) *identityIndex {
+	return &identityIndex{
+		byHash:  make(map[string]*identityRecord),
+		podHash: make(map[string]string),
+		nextID:  startID,
+	}
+}
+
+// ensureResult reports what ensure did so the caller can decide whether a
+// CiliumIdentity needs to be created.
+type ensureResult struct {
+	id      uint32
+	created bool
+}
+
+// ensure records that podKey is now associated with hash, allocating a new
+// identity if no pod currently references hash. If podKey was previously
+// associated with a different hash (its labels changed), that reference is
+// released first, which may report a gcResult for the old identity via the
+// returned released value.
+func (idx *identityIndex) ensure(podKey, hash string) (result ensureResult, released *gcResult) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if prevHash, ok := idx.podHash[podKey]; ok {
+		if prevHash == hash {
+			// No change; pod already owns an identity for this label set.
+			return ensureResult{id: idx.byHash[hash].id}, nil
+		}
+		released = idx.releaseLocked(podKey, prevHash)
+	}
+
+	idx.podHash[podKey] = hash
+
+	rec, ok := idx.byHash[hash]
+	if !ok {
+		rec = &identityRecord{id: idx.nextID, hash: hash, owners: make(map[string]struct{})}
+		idx.nextID++
+		idx.byHash[hash] = rec
+	}
+	rec.owners[podKey] = struct{}{}
+
+	return ensureResult{id: rec.id, created: !ok}, released
+}
+
+// gcResult reports that an identity lost its last owner and must be deleted.
+type gcResult struct {
+	id   uint32
+	hash string
+}
+
+// release drops podKey's reference to whatever identity it last held. It
+// returns the identity to garbage collect if podKey was its last owner.
+func (idx *identityIndex) release(podKey string) *gcResult {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	hash, ok := idx.podHash[podKey]
+	if !ok {
+		return nil
+	}
+	delete(idx.podHash, podKey)
+	return idx.releaseLocked(podKey, hash)
+}
+
+func (idx *identityIndex) releaseLocked(podKey, hash string) *gcResult {
+	rec, ok := idx.byHash[hash]
+	if !ok {
+		return nil
+	}
+	delete(rec.owners, podKey)
+	if len(rec.owners) > 0 {
+		return nil
+	}
+
+	delete(idx.byHash, hash)
+	return &gcResult{id: rec.id, hash: hash}
+}
+
+// forgetID drops bookkeeping for id regardless of ownership, used when a
+// CiliumIdentity this operator allocated was deleted out-of-band (e.g. by a
+// user running kubectl delete). The next pod reconcile for that label set
+// will simply re-allocate it.
+func (idx *identityIndex) forgetID(id uint32) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for hash, rec := range idx.byHash {
+		if rec.id != id {
+			continue
+		}
+		delete(idx.byHash, hash)
+		for podKey, podHash := range idx.podHash {
+			if podHash == hash {
+				delete(idx.podHash, podKey)
+			}
+		}
+		return
+	}
+}
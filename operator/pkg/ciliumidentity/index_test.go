@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package ciliumidentity
+
+import "testing"
+
+func TestIdentityIndexEnsureAllocatesOncePerHash(t *testing.T) {
+	idx := newIdentityIndex(100)
+
+	r1, released := idx.ensure("default/pod-a", "hash-1")
+	if released != nil {
+		t.Fatalf("expected no GC on first ensure, got %+v", released)
+	}
+	if !r1.created {
+		t.Fatalf("expected first ensure for a hash to allocate a new identity")
+	}
+
+	r2, released := idx.ensure("default/pod-b", "hash-1")
+	if released != nil {
+		t.Fatalf("expected no GC when a second pod shares an existing hash, got %+v", released)
+	}
+	if r2.created {
+		t.Fatalf("expected second ensure for the same hash to reuse the existing identity")
+	}
+	if r1.id != r2.id {
+		t.Fatalf("expected pods with identical labels to share an identity, got %d and %d", r1.id, r2.id)
+	}
+}
+
+func TestIdentityIndexReleaseGCsOnlyOnLastOwner(t *testing.T) {
+	idx := newIdentityIndex(100)
+
+	idx.ensure("default/pod-a", "hash-1")
+	idx.ensure("default/pod-b", "hash-1")
+
+	if gc := idx.release("default/pod-a"); gc != nil {
+		t.Fatalf("expected no GC while another pod still references the identity, got %+v", gc)
+	}
+
+	gc := idx.release("default/pod-b")
+	if gc == nil {
+		t.Fatalf("expected GC once the last owner releases the identity")
+	}
+}
+
+func TestIdentityIndexEnsureHandlesLabelChange(t *testing.T) {
+	idx := newIdentityIndex(100)
+
+	first, _ := idx.ensure("default/pod-a", "hash-1")
+
+	second, released := idx.ensure("default/pod-a", "hash-2")
+	if released == nil || released.id != first.id {
+		t.Fatalf("expected changing a pod's labels to release its old identity, got %+v", released)
+	}
+	if !second.created {
+		t.Fatalf("expected a never-before-seen hash to allocate a new identity")
+	}
+	if second.id == first.id {
+		t.Fatalf("expected a new identity to be allocated for the new label set")
+	}
+}
+
+func TestIdentityIndexForgetID(t *testing.T) {
+	idx := newIdentityIndex(100)
+
+	r, _ := idx.ensure("default/pod-a", "hash-1")
+	idx.forgetID(r.id)
+
+	// After forgetting, the same labels must allocate a fresh identity
+	// rather than silently reusing bookkeeping for a CiliumIdentity that no
+	// longer exists.
+	again, _ := idx.ensure("default/pod-b", "hash-1")
+	if !again.created {
+		t.Fatalf("expected forgetID to make ensure allocate a new identity for the same hash")
+	}
+}
@@ -0,0 +1,71 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package ciliumidentity
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+)
+
+// nonIdentityRelevantLabelKeys mirrors the built-in denylist pkg/labels
+// strips before computing an agent-local identity: labels Kubernetes
+// controllers stamp onto pods that vary per-replica or per-rollout rather
+// than describing the workload itself. Without this, a Deployment rollout
+// or StatefulSet scale-up gives every pod its own identity instead of
+// sharing one per equivalence class, defeating the point of Identity.
+//
+// This is a fixed subset of what pkg/labels' user-configurable
+// --label-prefix-filter ultimately controls; folding in that full,
+// cluster-operator-configurable filter (and namespace labels) so
+// operator- and agent-allocated identities are computed identically is
+// left for a follow-up.
+var nonIdentityRelevantLabelKeys = map[string]struct{}{
+	"pod-template-hash":                  {},
+	"pod-template-generation":            {},
+	"controller-revision-hash":           {},
+	"statefulset.kubernetes.io/pod-name": {},
+	"batch.kubernetes.io/job-name":       {},
+	"batch.kubernetes.io/controller-uid": {},
+	"apps.kubernetes.io/pod-index":       {},
+}
+
+// securityRelevantLabels returns the subset of labels that identify a pod's
+// equivalence class for identity purposes, dropping per-replica/per-rollout
+// labels Kubernetes controllers stamp on automatically (see
+// nonIdentityRelevantLabelKeys).
+func securityRelevantLabels(labels map[string]string) map[string]string {
+	filtered := make(map[string]string, len(labels))
+	for k, v := range labels {
+		if _, excluded := nonIdentityRelevantLabelKeys[k]; excluded {
+			continue
+		}
+		filtered[k] = v
+	}
+	return filtered
+}
+
+// hashLabels returns a stable identifier for a set of labels, used as the
+// key pods with identical security-relevant labels share a CiliumIdentity
+// under. Callers are expected to have already filtered labels through
+// securityRelevantLabels.
+func hashLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(labels[k])
+		b.WriteByte(';')
+	}
+
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,51 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package ciliumidentity
+
+import "testing"
+
+func TestHashLabelsIsOrderIndependent(t *testing.T) {
+	a := hashLabels(map[string]string{"app": "frontend", "env": "prod"})
+	b := hashLabels(map[string]string{"env": "prod", "app": "frontend"})
+
+	if a != b {
+		t.Fatalf("expected hashLabels to be independent of map iteration order, got %q and %q", a, b)
+	}
+}
+
+func TestHashLabelsDiffersOnValueChange(t *testing.T) {
+	a := hashLabels(map[string]string{"app": "frontend"})
+	b := hashLabels(map[string]string{"app": "backend"})
+
+	if a == b {
+		t.Fatalf("expected different label values to produce different hashes")
+	}
+}
+
+func TestSecurityRelevantLabelsDropsControllerStampedLabels(t *testing.T) {
+	filtered := securityRelevantLabels(map[string]string{
+		"app":                     "frontend",
+		"pod-template-hash":       "abc123",
+		"controller-revision-hash": "xyz789",
+	})
+
+	if _, ok := filtered["pod-template-hash"]; ok {
+		t.Fatalf("expected pod-template-hash to be filtered out, got %+v", filtered)
+	}
+	if _, ok := filtered["controller-revision-hash"]; ok {
+		t.Fatalf("expected controller-revision-hash to be filtered out, got %+v", filtered)
+	}
+	if filtered["app"] != "frontend" {
+		t.Fatalf("expected app label to survive filtering, got %+v", filtered)
+	}
+}
+
+func TestHashLabelsSharedAcrossReplicaSetRollout(t *testing.T) {
+	replicaOne := securityRelevantLabels(map[string]string{"app": "frontend", "pod-template-hash": "abc123"})
+	replicaTwo := securityRelevantLabels(map[string]string{"app": "frontend", "pod-template-hash": "def456"})
+
+	if hashLabels(replicaOne) != hashLabels(replicaTwo) {
+		t.Fatalf("expected pods from different ReplicaSet revisions with otherwise identical labels to share an identity")
+	}
+}
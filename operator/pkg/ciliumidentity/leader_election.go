@@ -0,0 +1,32 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package ciliumidentity
+
+// LeaderElection is the subset of the operator's leader-election machinery
+// that the CiliumIdentity controller needs. It lets the controller start
+// and stop its reconcile loop as leadership is gained and lost, without
+// depending on the concrete leaderelection.LeaderElector implementation.
+type LeaderElection interface {
+	// OnLeaderUpdate registers fn to be called with true once this operator
+	// instance starts leading, and with false when it stops (including on
+	// shutdown). It returns a function that unregisters fn.
+	OnLeaderUpdate(fn func(isLeader bool)) (unregister func())
+}
+
+// alwaysLeader is the LeaderElection used when nothing in the hive provides
+// a real implementation. It treats this operator instance as leader from
+// the moment it's registered until unregistered, which is only safe with a
+// single operator replica: running more than one with
+// operator-manages-identities enabled and no real LeaderElection wired in
+// will race, since every replica believes it's the leader.
+type alwaysLeader struct{}
+
+func newAlwaysLeader() *alwaysLeader {
+	return &alwaysLeader{}
+}
+
+func (alwaysLeader) OnLeaderUpdate(fn func(isLeader bool)) (unregister func()) {
+	fn(true)
+	return func() {}
+}
@@ -0,0 +1,209 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package identitycachecell
+
+import (
+	"log"
+	"net"
+	"sync"
+
+	"github.com/cilium/cilium/pkg/identity"
+	"github.com/cilium/cilium/pkg/node"
+	"github.com/cilium/cilium/pkg/option"
+	"github.com/cilium/cilium/pkg/policy"
+	"github.com/cilium/cilium/pkg/trigger"
+)
+
+// identityAllocatorOwner is used to break the circular dependency between
+// CachingIdentityAllocator and policy.Repository.
+//
+// Calls to UpdateIdentities are coalesced: rather than fanning every call
+// out to identityHandlers synchronously, added/deleted deltas are merged
+// into a pending batch and flushed by a single goroutine at most once per
+// IdentityUpdateBatchWindow. Reaching IdentityUpdateMaxInFlight pending
+// identities only changes the reason the batch is triggered with; it does
+// not flush the batch before the window has elapsed. This avoids a burst of
+// clustermesh or k8s identity churn turning into one endpoint regeneration
+// per identity.
+type identityAllocatorOwner struct {
+	policy        policy.PolicyRepository
+	policyUpdater *policy.Updater
+
+	identityHandlers []identity.UpdateIdentities
+
+	batchingEnabled bool
+	maxInFlight     int
+	batchTrigger    *trigger.Trigger
+
+	mu             sync.Mutex
+	pendingAdded   identity.IdentityMap
+	pendingDeleted identity.IdentityMap
+	rawDeltaCount  int
+	waiters        []chan struct{}
+}
+
+func newIdentityAllocatorOwner(policyRepo policy.PolicyRepository, policyUpdater *policy.Updater, handlers []identity.UpdateIdentities, cfg config) *identityAllocatorOwner {
+	iao := &identityAllocatorOwner{
+		policy:        policyRepo,
+		policyUpdater: policyUpdater,
+
+		identityHandlers: handlers,
+
+		batchingEnabled: cfg.EnableIdentityUpdateBatching,
+		maxInFlight:     cfg.IdentityUpdateMaxInFlight,
+
+		pendingAdded:   identity.IdentityMap{},
+		pendingDeleted: identity.IdentityMap{},
+	}
+
+	if iao.batchingEnabled {
+		t, err := trigger.NewTrigger(trigger.Parameters{
+			MinInterval: cfg.IdentityUpdateBatchWindow,
+			TriggerFunc: iao.drain,
+			Name:        "identity-update-batch",
+		})
+		if err != nil {
+			// MinInterval is always non-negative here, so NewTrigger cannot
+			// fail; fall back to the unbatched path rather than panicking.
+			log.Printf("failed to create identity update batch trigger, disabling batching: %s", err)
+			iao.batchingEnabled = false
+		} else {
+			iao.batchTrigger = t
+		}
+	}
+
+	return iao
+}
+
+// UpdateIdentities informs the policy package of all identity changes
+// and also triggers policy updates.
+//
+// The caller is responsible for making sure the same identity is not
+// present in both 'added' and 'deleted'.
+func (iao *identityAllocatorOwner) UpdateIdentities(added, deleted identity.IdentityMap) {
+	if !iao.batchingEnabled {
+		iao.flush(added, deleted)
+		return
+	}
+
+	iao.mu.Lock()
+	iao.rawDeltaCount += len(added) + len(deleted)
+	inFlight := mergeIdentityMap(iao.pendingAdded, iao.pendingDeleted, added, deleted)
+	exceeded := iao.maxInFlight > 0 && inFlight >= iao.maxInFlight
+	iao.mu.Unlock()
+
+	if exceeded {
+		iao.batchTrigger.TriggerWithReason("max in-flight identity updates reached")
+	} else {
+		iao.batchTrigger.TriggerWithReason("identities added or deleted")
+	}
+}
+
+// FlushIdentityUpdates blocks until every identity change enqueued by a
+// prior UpdateIdentities call has been applied to the selector cache and
+// endpoints. Callers that need an identity to be visible before a dependent
+// operation proceeds (e.g. restoring endpoints on startup) should call this
+// after the identities they depend on have been allocated.
+func (iao *identityAllocatorOwner) FlushIdentityUpdates() {
+	if !iao.batchingEnabled {
+		return
+	}
+
+	// Always wait for a drain, even with nothing pending: a drain already in
+	// progress has taken the pending maps but may not have applied them yet.
+	// batchTrigger runs drains one at a time, so the drain that closes done
+	// starts only after that one has finished.
+	iao.mu.Lock()
+	done := make(chan struct{})
+	iao.waiters = append(iao.waiters, done)
+	iao.mu.Unlock()
+
+	iao.batchTrigger.TriggerWithReason("FlushIdentityUpdates")
+	<-done
+}
+
+// Close stops the batching goroutine, flushing any pending changes first.
+func (iao *identityAllocatorOwner) Close() {
+	if iao.batchTrigger == nil {
+		return
+	}
+	iao.FlushIdentityUpdates()
+	iao.batchTrigger.Shutdown()
+}
+
+// drain is invoked by batchTrigger at most once per IdentityUpdateBatchWindow
+// and merges every change accumulated since the last drain into one
+// aggregated UpdateIdentities call.
+func (iao *identityAllocatorOwner) drain(_ []string) {
+	iao.mu.Lock()
+	added, deleted := iao.pendingAdded, iao.pendingDeleted
+	rawCount := iao.rawDeltaCount
+	waiters := iao.waiters
+	iao.pendingAdded = identity.IdentityMap{}
+	iao.pendingDeleted = identity.IdentityMap{}
+	iao.rawDeltaCount = 0
+	iao.waiters = nil
+	iao.mu.Unlock()
+
+	recordBatch(rawCount, len(added)+len(deleted))
+
+	if len(added) > 0 || len(deleted) > 0 {
+		iao.flush(added, deleted)
+	}
+
+	for _, w := range waiters {
+		close(w)
+	}
+}
+
+// flush fans a single added/deleted delta out to every identityHandler and
+// the policy selector cache, then triggers policy regeneration. This is the
+// same sequence UpdateIdentities used to perform directly before batching
+// was introduced.
+func (iao *identityAllocatorOwner) flush(added, deleted identity.IdentityMap) {
+	wg := &sync.WaitGroup{}
+	for _, handler := range iao.identityHandlers {
+		handler.UpdateIdentities(added, deleted, wg)
+	}
+	// Invoke policy selector cache always as the last handler
+	iao.policy.GetSelectorCache().UpdateIdentities(added, deleted, wg)
+	// Wait for update propagation to endpoints before triggering policy updates
+	wg.Wait()
+	iao.policyUpdater.TriggerPolicyUpdates(false, "one or more identities created or deleted")
+}
+
+// mergeIdentityMap merges src's added/deleted deltas into dstAdded/dstDeleted
+// in place, dropping any identity that appears in both maps after the merge
+// since a delete following an add (or vice versa) within the same window has
+// no net effect. It returns the resulting number of pending identities.
+func mergeIdentityMap(dstAdded, dstDeleted, srcAdded, srcDeleted identity.IdentityMap) int {
+	for id, lbls := range srcAdded {
+		delete(dstDeleted, id)
+		dstAdded[id] = lbls
+	}
+	for id, lbls := range srcDeleted {
+		delete(dstAdded, id)
+		dstDeleted[id] = lbls
+	}
+	return len(dstAdded) + len(dstDeleted)
+}
+
+// GetNodeSuffix returns the suffix to be appended to kvstore keys of this
+// agent
+func (iao *identityAllocatorOwner) GetNodeSuffix() string {
+	var ip net.IP
+
+	switch {
+	case option.Config.EnableIPv4:
+		ip = node.GetIPv4()
+	case option.Config.EnableIPv6:
+		ip = node.GetIPv6()
+	}
+
+	if ip == nil {
+		log.Fatal("Node IP not available yet")
+	}
+
+	return ip.String()
+}
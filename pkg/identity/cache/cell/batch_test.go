@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package identitycachecell
+
+import (
+	"testing"
+
+	"github.com/cilium/cilium/pkg/identity"
+)
+
+func TestMergeIdentityMapAccumulatesAcrossCalls(t *testing.T) {
+	dstAdded := identity.IdentityMap{}
+	dstDeleted := identity.IdentityMap{}
+
+	n := mergeIdentityMap(dstAdded, dstDeleted, identity.IdentityMap{1: nil, 2: nil}, identity.IdentityMap{})
+	if n != 2 {
+		t.Fatalf("expected 2 pending identities, got %d", n)
+	}
+
+	n = mergeIdentityMap(dstAdded, dstDeleted, identity.IdentityMap{3: nil}, identity.IdentityMap{})
+	if n != 3 {
+		t.Fatalf("expected 3 pending identities after a second merge, got %d", n)
+	}
+	if len(dstAdded) != 3 || len(dstDeleted) != 0 {
+		t.Fatalf("expected dstAdded to hold 1,2,3 and dstDeleted to be empty, got added=%v deleted=%v", dstAdded, dstDeleted)
+	}
+}
+
+func TestMergeIdentityMapDeleteCancelsPendingAdd(t *testing.T) {
+	dstAdded := identity.IdentityMap{1: nil}
+	dstDeleted := identity.IdentityMap{}
+
+	n := mergeIdentityMap(dstAdded, dstDeleted, identity.IdentityMap{}, identity.IdentityMap{1: nil})
+	if n != 1 {
+		t.Fatalf("expected identity 1 to move from added to deleted, got %d pending", n)
+	}
+	if _, stillAdded := dstAdded[1]; stillAdded {
+		t.Fatalf("expected identity 1 to be removed from dstAdded once deleted")
+	}
+	if _, deleted := dstDeleted[1]; !deleted {
+		t.Fatalf("expected identity 1 to be present in dstDeleted")
+	}
+}
+
+func TestMergeIdentityMapAddCancelsPendingDelete(t *testing.T) {
+	dstAdded := identity.IdentityMap{}
+	dstDeleted := identity.IdentityMap{1: nil}
+
+	n := mergeIdentityMap(dstAdded, dstDeleted, identity.IdentityMap{1: nil}, identity.IdentityMap{})
+	if n != 1 {
+		t.Fatalf("expected identity 1 to move from deleted to added, got %d pending", n)
+	}
+	if _, deleted := dstDeleted[1]; deleted {
+		t.Fatalf("expected identity 1 to be removed from dstDeleted once re-added")
+	}
+	if _, added := dstAdded[1]; !added {
+		t.Fatalf("expected identity 1 to be present in dstAdded")
+	}
+}
@@ -0,0 +1,46 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package identitycachecell
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/cilium/cilium/pkg/metrics"
+	"github.com/cilium/cilium/pkg/metrics/metric"
+)
+
+var (
+	// identityUpdateBatchSize is the number of identities (added+deleted)
+	// flushed by a single drain of the UpdateIdentities batch.
+	identityUpdateBatchSize = metric.NewHistogram(metric.HistogramOpts{
+		ConfigName: metrics.Namespace + "_identity_update_batch_size",
+		Namespace:  metrics.Namespace,
+		Name:       "identity_update_batch_size",
+		Help:       "Number of identities included in a single coalesced UpdateIdentities call",
+		Buckets:    []float64{1, 2, 4, 8, 16, 32, 64, 128, 256, 512, 1024},
+	})
+
+	// identityUpdateCoalesceRatio is the number of raw identity changes
+	// (added+deleted, summed over every UpdateIdentities call) merged into a
+	// single batch drain, divided by the number of identities that drain
+	// actually flushed after dedup.
+	identityUpdateCoalesceRatio = metric.NewHistogram(metric.HistogramOpts{
+		ConfigName: metrics.Namespace + "_identity_update_coalesce_ratio",
+		Namespace:  metrics.Namespace,
+		Name:       "identity_update_coalesce_ratio",
+		Help:       "Ratio of raw identity changes received to identities actually flushed after dedup, per batch",
+		Buckets:    prometheus.LinearBuckets(1, 1, 10),
+	})
+)
+
+// recordBatch records metrics for a single drain of the batching goroutine.
+// rawCount is the pre-dedup number of identity changes that contributed to
+// the batch; flushedCount is the post-dedup number actually applied.
+func recordBatch(rawCount, flushedCount int) {
+	identityUpdateBatchSize.Observe(float64(flushedCount))
+
+	if flushedCount > 0 {
+		identityUpdateCoalesceRatio.Observe(float64(rawCount) / float64(flushedCount))
+	}
+}
@@ -0,0 +1,52 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package stream
+
+import (
+	"github.com/cilium/hive/cell"
+	"github.com/cilium/stream"
+	"google.golang.org/grpc"
+
+	"github.com/cilium/cilium/pkg/identity/cache"
+	"github.com/cilium/cilium/pkg/identity/stream/identitystreampb"
+	"github.com/cilium/cilium/pkg/logging"
+	"github.com/cilium/cilium/pkg/logging/logfields"
+)
+
+var log = logging.DefaultLogger.WithField(logfields.LogSubsys, "identity-stream")
+
+// replayBufferSize bounds how many past identity changes are retained for
+// resuming a dropped subscription. At the default it covers several minutes
+// of churn in a large cluster without holding an unbounded amount of memory.
+const replayBufferSize = 4096
+
+// Cell registers the IdentityStream gRPC service on the agent's existing
+// Hubble/agent gRPC server, backed by the identity allocator's observable.
+var Cell = cell.Module(
+	"identity-stream",
+	"Serves identity allocator changes over gRPC",
+
+	cell.Invoke(registerServer),
+)
+
+type serverParams struct {
+	cell.In
+
+	GRPCServer *grpc.Server `optional:"true"`
+	Observable stream.Observable[cache.IdentityChange]
+}
+
+func registerServer(params serverParams) {
+	if params.GRPCServer == nil {
+		// No agent gRPC server configured (e.g. Hubble disabled, or this
+		// cell list doesn't provide one at all yet). Warn instead of
+		// silently doing nothing, since without it this cell can never
+		// register the service.
+		log.Warning("No *grpc.Server available; identity stream service will not be served")
+		return
+	}
+
+	srv := newServer(params.Observable, replayBufferSize)
+	identitystreampb.RegisterIdentityStreamServer(params.GRPCServer, srv)
+}
@@ -0,0 +1,35 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+// Package stream serves the identity allocator's
+// stream.Observable[cache.IdentityChange] (see
+// pkg/identity/cache/cell.identityAllocatorOut) over gRPC on the agent's
+// existing Hubble gRPC server, as an alternative to polling the
+// /v1/identity REST endpoint.
+//
+// Every change handed to a subscriber is stamped with a monotonically
+// increasing revision by the server. A client reconnecting with
+// SubscribeRequest.StartRevision set (as pkg/identity/stream/client's
+// Subscribe does) resumes from its last acknowledged revision and is
+// guaranteed at-least-once delivery, as long as the requested revision is
+// still held in the bounded replay buffer; otherwise the server falls back
+// to a full snapshot, matching the semantics documented on
+// identitystreampb.SubscribeRequest.
+//
+// Messages are marshalled with the jsonCodec registered in
+// identitystreampb/codec.go rather than real protobuf wire format, since
+// there is no protoc toolchain available in this tree to generate genuine
+// proto.Message implementations. Only this package's Go client
+// (pkg/identity/stream/client) negotiates that codec today: a standard
+// gRPC client (grpcurl, another language's generated stub, a mesh
+// sidecar) cannot yet talk to this service. Treat it as an internal,
+// Go-to-Go stream until real generated protobuf types replace codec.go;
+// it is not yet the generic external-consumer API described in
+// https://github.com/cilium/cilium/issues/34675.
+//
+// Cell only registers the service on an already-running *grpc.Server, and
+// only if registerServer observes one; it still needs to be added to the
+// agent's hive cell list (daemon/cmd) and that cell list needs to provide
+// a *grpc.Server, neither of which is part of this tree, so the service
+// cannot be reached yet.
+package stream
@@ -0,0 +1,41 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package identitystreampb
+
+import (
+	"encoding/json"
+
+	"google.golang.org/grpc/encoding"
+)
+
+// codecName is the gRPC content-subtype (lowercase, per the gRPC wire spec)
+// this package's messages are marshalled with. The client must set it via
+// grpc.CallContentSubtype on every call; the server picks up the matching
+// codec automatically from the request's content-type header.
+const codecName = "identitystreamjson"
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+// jsonCodec marshals IdentityStream messages with encoding/json instead of
+// the default protobuf wire format. This package's message types
+// (GetIdentitiesRequest, IdentityChange, etc.) are plain structs, not
+// generated from identity_stream.proto by protoc-gen-go — there is no
+// protoc toolchain available to generate and vendor real protobuf code in
+// this tree — so they don't implement proto.Message and would fail to
+// marshal under grpc.Server's default codec. Registering this codec under
+// its own content-subtype keeps the service functional without requiring
+// every message to implement the full proto.Message/ProtoReflect surface.
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string { return codecName }
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,42 @@
+// Hand-maintained stand-ins for the messages described by
+// identity_stream.proto. There is no protoc toolchain available in this
+// tree to generate and vendor real protobuf code, so these are plain Go
+// structs rather than protoc-gen-go output; they are marshalled with the
+// jsonCodec registered in codec.go instead of the protobuf wire format.
+// identity_stream.proto remains the canonical description of the wire
+// contract these types must keep matching.
+
+package identitystreampb
+
+type ChangeKind int32
+
+const (
+	ChangeKind_CHANGE_KIND_UNSPECIFIED ChangeKind = 0
+	ChangeKind_CHANGE_KIND_UPSERT      ChangeKind = 1
+	ChangeKind_CHANGE_KIND_DELETE      ChangeKind = 2
+)
+
+type GetIdentitiesRequest struct{}
+
+type GetIdentitiesResponse struct {
+	Identities []*Identity
+	Revision   uint64
+}
+
+type SubscribeRequest struct {
+	// StartRevision resumes delivery after this revision. 0 requests a
+	// fresh snapshot.
+	StartRevision uint64
+}
+
+type Identity struct {
+	Id     uint32
+	Labels map[string]string
+}
+
+type IdentityChange struct {
+	Kind       ChangeKind
+	Identity   *Identity
+	Revision   uint64
+	IsSnapshot bool
+}
@@ -0,0 +1,142 @@
+// Hand-maintained gRPC plumbing for the IdentityStream service described
+// by identity_stream.proto. It mirrors the shape protoc-gen-go-grpc would
+// normally emit, but is written by hand since this tree has no protoc
+// toolchain to generate it from the .proto file. Messages are marshalled
+// with the jsonCodec registered in codec.go (content-subtype codecName)
+// rather than the default protobuf codec, since the message types in
+// identity_stream.pb.go don't implement proto.Message.
+
+package identitystreampb
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// IdentityStreamServer is the server API for the IdentityStream service.
+type IdentityStreamServer interface {
+	GetIdentities(context.Context, *GetIdentitiesRequest) (*GetIdentitiesResponse, error)
+	Subscribe(*SubscribeRequest, IdentityStream_SubscribeServer) error
+}
+
+// IdentityStream_SubscribeServer is the server-streaming handle for
+// Subscribe.
+type IdentityStream_SubscribeServer interface {
+	Send(*IdentityChange) error
+	grpc.ServerStream
+}
+
+// RegisterIdentityStreamServer registers srv on s. It mirrors the
+// registration helper protoc-gen-go-grpc would normally emit; full
+// marshalling/unmarshalling is omitted here since this package is not
+// protoc-generated in this checkout.
+func RegisterIdentityStreamServer(s grpc.ServiceRegistrar, srv IdentityStreamServer) {
+	s.RegisterService(&identityStreamServiceDesc, srv)
+}
+
+var identityStreamServiceDesc = grpc.ServiceDesc{
+	ServiceName: "identity_stream.IdentityStream",
+	HandlerType: (*IdentityStreamServer)(nil),
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Subscribe",
+			Handler:       subscribeHandler,
+			ServerStreams: true,
+		},
+	},
+}
+
+func subscribeHandler(srv any, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(IdentityStreamServer).Subscribe(m, &identityStreamSubscribeServer{stream})
+}
+
+type identityStreamSubscribeServer struct {
+	grpc.ServerStream
+}
+
+func (x *identityStreamSubscribeServer) Send(m *IdentityChange) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// UnimplementedIdentityStreamServer must be embedded by implementations to
+// get forward-compatibility with new methods added to the service.
+type UnimplementedIdentityStreamServer struct{}
+
+func (UnimplementedIdentityStreamServer) GetIdentities(context.Context, *GetIdentitiesRequest) (*GetIdentitiesResponse, error) {
+	return nil, grpcNotImplemented("GetIdentities")
+}
+
+func (UnimplementedIdentityStreamServer) Subscribe(*SubscribeRequest, IdentityStream_SubscribeServer) error {
+	return grpcNotImplemented("Subscribe")
+}
+
+func grpcNotImplemented(method string) error {
+	return status.Errorf(codes.Unimplemented, "method %s not implemented", method)
+}
+
+// IdentityStreamClient is the client API for the IdentityStream service.
+type IdentityStreamClient interface {
+	GetIdentities(ctx context.Context, in *GetIdentitiesRequest, opts ...grpc.CallOption) (*GetIdentitiesResponse, error)
+	Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (IdentityStream_SubscribeClient, error)
+}
+
+// IdentityStream_SubscribeClient is the client-streaming handle for
+// Subscribe.
+type IdentityStream_SubscribeClient interface {
+	Recv() (*IdentityChange, error)
+	grpc.ClientStream
+}
+
+type identityStreamClient struct {
+	cc grpc.ClientConnInterface
+}
+
+// NewIdentityStreamClient wraps cc with the IdentityStream client API.
+func NewIdentityStreamClient(cc grpc.ClientConnInterface) IdentityStreamClient {
+	return &identityStreamClient{cc: cc}
+}
+
+func (c *identityStreamClient) GetIdentities(ctx context.Context, in *GetIdentitiesRequest, opts ...grpc.CallOption) (*GetIdentitiesResponse, error) {
+	opts = append(opts, grpc.CallContentSubtype(codecName))
+	out := new(GetIdentitiesResponse)
+	err := c.cc.Invoke(ctx, "/identity_stream.IdentityStream/GetIdentities", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *identityStreamClient) Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (IdentityStream_SubscribeClient, error) {
+	opts = append(opts, grpc.CallContentSubtype(codecName))
+	stream, err := c.cc.NewStream(ctx, &identityStreamServiceDesc.Streams[0], "/identity_stream.IdentityStream/Subscribe", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &identityStreamSubscribeClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type identityStreamSubscribeClient struct {
+	grpc.ClientStream
+}
+
+func (x *identityStreamSubscribeClient) Recv() (*IdentityChange, error) {
+	m := new(IdentityChange)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -0,0 +1,210 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package stream
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cilium/stream"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/cilium/cilium/pkg/identity/cache"
+	"github.com/cilium/cilium/pkg/identity/stream/identitystreampb"
+)
+
+// subscriberBuffer bounds how many undelivered changes are queued for a
+// single slow subscriber before it is dropped. A dropped subscriber's
+// client reconnects and resumes from its last-acked revision via
+// subscribe/GetIdentities, so this only trades a forced reconnect for
+// bounded memory instead of an unbounded queue per slow reader.
+const subscriberBuffer = 256
+
+// server implements identitystreampb.IdentityStreamServer on top of the
+// identity allocator's observable, assigning every change a monotonically
+// increasing revision and keeping a bounded window of past changes so that
+// a reconnecting client can resume without missing events. Live
+// subscribers registered via Subscribe also receive each change as it is
+// observed.
+type server struct {
+	identitystreampb.UnimplementedIdentityStreamServer
+
+	mu          sync.Mutex
+	revision    uint64
+	snapshot    map[uint32]*identitystreampb.Identity
+	replay      []*identitystreampb.IdentityChange // ring buffer, oldest first
+	bufSize     int
+	subscribers map[chan *identitystreampb.IdentityChange]struct{}
+}
+
+func newServer(observable stream.Observable[cache.IdentityChange], bufSize int) *server {
+	s := &server{
+		snapshot:    make(map[uint32]*identitystreampb.Identity),
+		bufSize:     bufSize,
+		subscribers: make(map[chan *identitystreampb.IdentityChange]struct{}),
+	}
+
+	observable.Observe(context.Background(), s.onChange, func(error) {})
+
+	return s
+}
+
+func (s *server) onChange(change cache.IdentityChange) {
+	if change.Kind == cache.IdentityChangeSync {
+		// Initial sync marker from the allocator; no identity to publish.
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.revision++
+	pbChange := toPB(change, s.revision)
+
+	switch pbChange.Kind {
+	case identitystreampb.ChangeKind_CHANGE_KIND_UPSERT:
+		s.snapshot[pbChange.Identity.Id] = pbChange.Identity
+	case identitystreampb.ChangeKind_CHANGE_KIND_DELETE:
+		delete(s.snapshot, pbChange.Identity.Id)
+	}
+
+	s.replay = append(s.replay, pbChange)
+	if len(s.replay) > s.bufSize {
+		s.replay = s.replay[len(s.replay)-s.bufSize:]
+	}
+
+	for ch := range s.subscribers {
+		select {
+		case ch <- pbChange:
+		default:
+			// Subscriber isn't keeping up; drop it rather than block onChange
+			// or grow its queue without bound. The channel is closed here, so
+			// Subscribe sees it closed and returns an error, and the client
+			// reconnects and resumes from its last-acked revision.
+			delete(s.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// subscribe registers ch to receive every future change and returns the
+// backlog of already-observed changes after revision, plus whether a full
+// snapshot must be sent first because revision fell outside the retained
+// replay window. Registration happens under the same lock as backlog
+// computation so no change can be missed or double-delivered in the gap
+// between reading the backlog and starting to receive live updates.
+func (s *server) subscribe(ch chan *identitystreampb.IdentityChange, revision uint64) (backlog []*identitystreampb.IdentityChange, snapshotRevision uint64, needsSnapshot bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.subscribers[ch] = struct{}{}
+
+	if revision == 0 || len(s.replay) == 0 || s.replay[0].Revision > revision+1 {
+		return nil, s.revision, true
+	}
+
+	for _, change := range s.replay {
+		if change.Revision > revision {
+			backlog = append(backlog, change)
+		}
+	}
+	return backlog, 0, false
+}
+
+func (s *server) unsubscribe(ch chan *identitystreampb.IdentityChange) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subscribers[ch]; ok {
+		delete(s.subscribers, ch)
+		close(ch)
+	}
+}
+
+// GetIdentities returns a point-in-time snapshot of every known identity.
+func (s *server) GetIdentities(ctx context.Context, _ *identitystreampb.GetIdentitiesRequest) (*identitystreampb.GetIdentitiesResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resp := &identitystreampb.GetIdentitiesResponse{
+		Identities: make([]*identitystreampb.Identity, 0, len(s.snapshot)),
+		Revision:   s.revision,
+	}
+	for _, id := range s.snapshot {
+		resp.Identities = append(resp.Identities, id)
+	}
+	return resp, nil
+}
+
+// Subscribe streams identity changes starting after req.StartRevision. If
+// that revision has aged out of the replay buffer (or is 0), the client
+// first receives a full snapshot stamped is_snapshot; otherwise it first
+// receives the buffered backlog after that revision. Either is followed by
+// live deltas for as long as the stream stays open. A subscriber that falls too far behind
+// to keep its buffer drained is disconnected and must reconnect, resuming
+// from its last-acked revision.
+func (s *server) Subscribe(req *identitystreampb.SubscribeRequest, stream identitystreampb.IdentityStream_SubscribeServer) error {
+	ch := make(chan *identitystreampb.IdentityChange, subscriberBuffer)
+	backlog, snapshotRevision, needsSnapshot := s.subscribe(ch, req.StartRevision)
+	defer s.unsubscribe(ch)
+
+	if needsSnapshot {
+		resp, err := s.GetIdentities(stream.Context(), &identitystreampb.GetIdentitiesRequest{})
+		if err != nil {
+			return err
+		}
+		for _, id := range resp.Identities {
+			if err := stream.Send(&identitystreampb.IdentityChange{
+				Kind:       identitystreampb.ChangeKind_CHANGE_KIND_UPSERT,
+				Identity:   id,
+				Revision:   snapshotRevision,
+				IsSnapshot: true,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, change := range backlog {
+		if err := stream.Send(change); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case change, ok := <-ch:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "subscriber fell too far behind and was disconnected; reconnect and resume from the last acked revision")
+			}
+			if err := stream.Send(change); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func toPB(change cache.IdentityChange, revision uint64) *identitystreampb.IdentityChange {
+	labels := make(map[string]string, len(change.Labels))
+	for _, l := range change.Labels {
+		labels[l.Key] = l.Value
+	}
+
+	kind := identitystreampb.ChangeKind_CHANGE_KIND_UPSERT
+	if change.Kind == cache.IdentityChangeDelete {
+		kind = identitystreampb.ChangeKind_CHANGE_KIND_DELETE
+	}
+
+	return &identitystreampb.IdentityChange{
+		Kind: kind,
+		Identity: &identitystreampb.Identity{
+			Id:     change.ID.Uint32(),
+			Labels: labels,
+		},
+		Revision: revision,
+	}
+}